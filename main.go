@@ -1,82 +1,128 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/docopt/docopt-go"
-	"github.com/fzzy/radix/redis"
+	"github.com/dreamersdw/redis-view/pkg/redisview"
 	"github.com/mgutz/ansi"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	version = "0.1"
 	usage   = `Usage:
-	redis-view [--url=URL] [--sep=SEP] [--only-keys] [--nowrap] [PATTERN...]
+	redis-view [--url=URL] [--sep=SEP] [--only-keys] [--nowrap] [--scan-count=N] [--concurrency=N] [--cluster] [--sentinel=SPEC] [--stream-count=N] [--as-bitmap=PATTERN] [--geo=PATTERN] [--hll=PATTERN] [--type=TYPE] [--format=FORMAT] [--binary=MODE] [--tui] [PATTERN...]
 	redis-view --version
 	redis-view --help
 
 Example:
-	redis-view 'tasks:*' 'metrics:*' `
+	redis-view 'tasks:*' 'metrics:*'
+	redis-view --url=rediss://:secret@cache.example.com/2 'sessions:*'
+	redis-view --cluster --url=redis://node1.example.com:6379 'orders:*'
+	redis-view --sentinel=mymaster,sentinel1:26379,sentinel2:26379 'orders:*'
+	redis-view --geo='places:*' --as-bitmap='flags:*' --hll='visitors:*' '*'
+	redis-view --format=ndjson 'tasks:*' | jq .
+	redis-view --tui 'sessions:*'
+	redis-view --binary=hex 'blobs:*'`
 )
 
 var (
-	redisClient *redis.Client
+	ctx         = context.Background()
+	rdb         redis.Cmdable
 	wrap        bool
 	turnOnColor bool
 	redisURL    = "redis://127.0.0.1:6379"
 	patterns    = []string{"*"}
 	keySep      = ":"
-	onlyKeys    = false
+	format      = "tree"
+	binaryMode  = "auto"
+	walkOpts    redisview.Options
 )
 
 type treeNode struct {
 	value    string
 	children map[string]treeNode
+	fetched  bool
+	rtype    string
+	ttl      int64
+	val      interface{}
 }
 
-func getConn() *redis.Client {
-	if redisClient == nil {
-		URL, err := url.Parse(redisURL)
-		if err != nil {
-			fmt.Printf("fail to parse url '%s'\n", redisURL)
-			os.Exit(1)
-		}
-
-		address := URL.Host
-		if !strings.Contains(address, ":") {
-			address = fmt.Sprintf("%s:%d", URL.Host, 6379)
-		}
+// connectRedis builds the Cmdable used for the rest of the program. go-redis's
+// pooled clients are safe for concurrent use, so a single instance is shared
+// by every scan/fetch worker instead of dialing one socket per goroutine.
+func connectRedis(url string, cluster bool, sentinelSpec string) redis.Cmdable {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		fmt.Printf("fail to parse url '%s'\n", url)
+		os.Exit(1)
+	}
 
-		client, err := redis.Dial("tcp", address)
-		if err != nil {
-			fmt.Printf("unable connect to redis server\n")
+	if sentinelSpec != "" {
+		parts := strings.Split(sentinelSpec, ",")
+		if len(parts) < 2 {
+			fmt.Printf("--sentinel expects 'master-name,host:port,...'\n")
 			os.Exit(1)
 		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    parts[0],
+			SentinelAddrs: parts[1:],
+			Password:      opts.Password,
+			DB:            opts.DB,
+			TLSConfig:     opts.TLSConfig,
+		})
+	}
 
-		redisClient = client
+	if cluster {
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     []string{opts.Addr},
+			Password:  opts.Password,
+			TLSConfig: opts.TLSConfig,
+		})
 	}
-	return redisClient
+
+	return redis.NewClient(opts)
 }
 
-func populate(tree *treeNode, keys []string, sep string) {
-	for _, key := range keys {
-		var node = *tree
-		for _, part := range strings.Split(key, sep) {
-			_, ok := node.children[part]
-			if !ok {
-				node.children[part] = treeNode{
-					value:    part,
-					children: make(map[string]treeNode)}
-			}
-			node = node.children[part]
+// populateEntry inserts a single resolved redisview.Entry into the tree.
+func populateEntry(tree *treeNode, entry redisview.Entry, sep string) {
+	var node = *tree
+	parts := strings.Split(entry.Key, sep)
+	for i, part := range parts {
+		child, ok := node.children[part]
+		if !ok {
+			child = treeNode{value: part, children: make(map[string]treeNode)}
 		}
+		if i == len(parts)-1 {
+			child.fetched = true
+			child.rtype = entry.Type
+			child.ttl = entry.TTL
+			child.val = entry.Value
+		}
+		node.children[part] = child
+		node = node.children[part]
+	}
+}
+
+// buildTree drains a redisview.Walk stream into a tree, attaching each
+// resolved key as soon as it arrives. Used by the renderers whose output
+// format (a single nested document or box-drawn tree) can't be emitted
+// until the full tree is known; NDJSONRenderer skips this and streams
+// straight off the channel instead.
+func buildTree(tree *treeNode, entries <-chan redisview.Entry, sep string) {
+	for entry := range entries {
+		populateEntry(tree, entry, sep)
 	}
 }
 
@@ -89,49 +135,17 @@ func mapKeys(m map[string]treeNode) []string {
 	return keys
 }
 
-func query(key string) (rtype string, ttl int64, val interface{}) {
-	r := getConn()
-
-	rtype, _ = r.Cmd("type", key).Str()
-	ttl, _ = r.Cmd("ttl", key).Int64()
-
-	if onlyKeys {
-		val = ""
-		return
-	}
-
-	switch rtype {
-	case "string":
-		val, _ = r.Cmd("get", key).Str()
-	case "list":
-		val, _ = r.Cmd("lrange", key, 0, -1).List()
-	case "set":
-		val, _ = r.Cmd("smembers", key).List()
-	case "hash":
-		val, _ = r.Cmd("hgetall", key).Hash()
-	case "zset":
-		val, _ = r.Cmd("zrange", key, 0, -1, "WITHSCORES").Hash()
-	}
-	return
-}
-
+// isBinary reports whether bytes should be treated as opaque binary rather
+// than displayable text. Valid UTF-8 is never binary, however short; invalid
+// UTF-8 is always binary, since it can only come from a non-text encoding
+// (a byte-class threshold over it still misclassifies printable-but-invalid
+// data like a PNG header as text).
 func isBinary(bytes []byte) bool {
 	if len(bytes) == 0 {
 		return false
 	}
 
-	invisible := 0
-	for _, b := range bytes {
-		if (32 <= b && b < 127) || b == '\n' || b == '\t' || b == 'r' || b == 'f' || b == 'b' {
-		} else {
-			invisible++
-		}
-	}
-
-	if float64(invisible)/float64(len(bytes)) >= 0.3 {
-		return true
-	}
-	return false
+	return !utf8.Valid(bytes)
 }
 
 func bitset(bytes []byte) []byte {
@@ -149,6 +163,61 @@ func bitset(bytes []byte) []byte {
 	return seq
 }
 
+// hexDump renders data as a hexdump -C style block, one row of 16 bytes at a
+// time, indented so it lines up under the tree prefix of the value it
+// belongs to.
+func hexDump(data []byte, prefix string) string {
+	lines := make([]string, 0, len(data)/16+1)
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[offset:end]
+
+		hexCols := make([]string, 16)
+		ascii := make([]byte, len(chunk))
+		for i := 0; i < 16; i++ {
+			if i < len(chunk) {
+				hexCols[i] = fmt.Sprintf("%02x", chunk[i])
+				if chunk[i] >= 32 && chunk[i] < 127 {
+					ascii[i] = chunk[i]
+				} else {
+					ascii[i] = '.'
+				}
+			} else {
+				hexCols[i] = "  "
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf("%08x  %s  %s  |%s|",
+			offset, strings.Join(hexCols[:8], " "), strings.Join(hexCols[8:], " "), ascii))
+	}
+	return strings.Join(lines, "\n"+prefix)
+}
+
+// renderString formats a single string value according to --binary: auto
+// detects binary data and falls back to a hexdump, while hex/base64/bits/utf8
+// force a specific rendering regardless of content.
+func renderString(s string, prefix string) string {
+	data := []byte(s)
+	switch binaryMode {
+	case "hex":
+		return hexDump(data, prefix)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data)
+	case "bits":
+		return string(bitset(data))
+	case "utf8":
+		return s
+	default:
+		if isBinary(data) {
+			return hexDump(data, prefix)
+		}
+		return s
+	}
+}
+
 func prettyPrint(val interface{}, prefix string, wrap bool, isLast bool) string {
 	var result []byte
 	var newPrefix = prefix
@@ -157,22 +226,55 @@ func prettyPrint(val interface{}, prefix string, wrap bool, isLast bool) string
 	}
 	switch val.(type) {
 	case map[string]string:
-		if !wrap || len(val.(map[string]string)) <= 1 {
-			result, _ = json.Marshal(val)
+		m := val.(map[string]string)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rendered := make([]string, len(keys))
+		for i, k := range keys {
+			rendered[i] = fmt.Sprintf("%s: %s", k, renderString(m[k], newPrefix))
+		}
+		if !wrap || len(rendered) <= 1 {
+			result = []byte(strings.Join(rendered, ", "))
 		} else {
-			result, _ = json.MarshalIndent(val, newPrefix, "   ")
+			result = []byte(strings.Join(rendered, "\n"+newPrefix))
 		}
 	case []string:
-		if !wrap || len(val.([]string)) <= 1 {
-			result, _ = json.Marshal(val)
+		items := val.([]string)
+		rendered := make([]string, len(items))
+		for i, item := range items {
+			rendered[i] = renderString(item, newPrefix)
+		}
+		if !wrap || len(rendered) <= 1 {
+			result = []byte(strings.Join(rendered, ", "))
 		} else {
-			result, _ = json.MarshalIndent(val, newPrefix, "   ")
+			result = []byte(strings.Join(rendered, "\n"+newPrefix))
 		}
 	case string:
-		result = []byte(val.(string))
-		if isBinary(result) {
-			result = bitset(result)
+		result = []byte(renderString(val.(string), newPrefix))
+	case int64:
+		result = []byte(strconv.FormatInt(val.(int64), 10))
+	case redisview.BitmapView:
+		bm := val.(redisview.BitmapView)
+		result = []byte(fmt.Sprintf("%d bits set %s", bm.Count, bm.Bits))
+	case redisview.StreamView:
+		sv := val.(redisview.StreamView)
+		lines := make([]string, 0, len(sv.Entries)+1)
+		lines = append(lines, fmt.Sprintf("length=%d", sv.Length))
+		for _, entry := range sv.Entries {
+			fields, _ := json.Marshal(entry.Fields)
+			lines = append(lines, fmt.Sprintf("%s -> %s", entry.ID, fields))
 		}
+		result = []byte(strings.Join(lines, "\n"+newPrefix))
+	case []redisview.GeoPoint:
+		points := val.([]redisview.GeoPoint)
+		lines := make([]string, len(points))
+		for i, p := range points {
+			lines[i] = fmt.Sprintf("%f,%f", p.Lat, p.Lon)
+		}
+		result = []byte(strings.Join(lines, "\n"+newPrefix))
 	}
 	return string(result)
 }
@@ -184,6 +286,24 @@ func colorize(s string, style string) string {
 	return s
 }
 
+// Renderer turns a redisview.Walk entry stream into output on stdout.
+// NDJSONRenderer prints each entry as soon as it arrives, matching its
+// "stream through jq" pitch; the others need a complete, correctly nested
+// document before anything they emit would parse, so they buffer the
+// stream into a tree first.
+type Renderer interface {
+	Render(entries <-chan redisview.Entry, sep string)
+}
+
+// TreeRenderer is the original colourized box-drawing tree.
+type TreeRenderer struct{}
+
+func (TreeRenderer) Render(entries <-chan redisview.Entry, sep string) {
+	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
+	buildTree(tree, entries, sep)
+	plot(*tree, "", "")
+}
+
 func plotNode(node treeNode, key string, leading string, isLast bool) {
 	var sep string
 	if isLast {
@@ -193,18 +313,16 @@ func plotNode(node treeNode, key string, leading string, isLast bool) {
 	}
 
 	var extra string
-	if len(node.children) == 0 {
-		rtype, ttl, val := query(key)
-
+	if len(node.children) == 0 && node.fetched {
 		var sttl = ""
-		if ttl != -1 {
-			sttl = strconv.Itoa(int(ttl))
+		if node.ttl != -1 {
+			sttl = strconv.Itoa(int(node.ttl))
 		}
 
 		extra = fmt.Sprintf("%s %s %s %s", "#",
-			colorize(rtype, "yellow"),
+			colorize(node.rtype, "yellow"),
 			colorize(sttl, "red"),
-			prettyPrint(val, leading, wrap, isLast))
+			prettyPrint(node.val, leading, wrap, isLast))
 	}
 
 	nodeVal := colorize(node.value, "blue")
@@ -233,6 +351,136 @@ func plot(node treeNode, key string, leading string) {
 	}
 }
 
+// jsonNode mirrors the tree as a nested object, one level per key segment.
+type jsonNode struct {
+	Type     string               `json:"_type,omitempty" yaml:"_type,omitempty"`
+	TTL      int64                `json:"_ttl,omitempty" yaml:"_ttl,omitempty"`
+	Value    interface{}          `json:"_value,omitempty" yaml:"_value,omitempty"`
+	Children map[string]*jsonNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// jsonSafeString base64-encodes s when it's binary, since json.Marshal
+// otherwise silently mangles invalid UTF-8 into U+FFFD.
+func jsonSafeString(s string) string {
+	if isBinary([]byte(s)) {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	return s
+}
+
+// jsonSafeValue applies jsonSafeString to every string a value carries, so
+// JSONRenderer/YAMLRenderer/NDJSONRenderer never corrupt binary data the way
+// a direct json.Marshal/yaml.Marshal of node.val would.
+func jsonSafeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return jsonSafeString(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = jsonSafeString(s)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			out[k] = jsonSafeString(s)
+		}
+		return out
+	case redisview.StreamView:
+		entries := make([]redisview.StreamEntry, len(v.Entries))
+		for i, e := range v.Entries {
+			fields := make(map[string]string, len(e.Fields))
+			for k, s := range e.Fields {
+				fields[k] = jsonSafeString(s)
+			}
+			entries[i] = redisview.StreamEntry{ID: e.ID, Fields: fields}
+		}
+		return redisview.StreamView{Length: v.Length, Entries: entries}
+	default:
+		return val
+	}
+}
+
+func toJSONNode(node treeNode) *jsonNode {
+	out := &jsonNode{}
+	if node.fetched {
+		out.Type = node.rtype
+		out.TTL = node.ttl
+		out.Value = jsonSafeValue(node.val)
+	}
+	if len(node.children) > 0 {
+		out.Children = make(map[string]*jsonNode, len(node.children))
+		for part, child := range node.children {
+			out.Children[part] = toJSONNode(child)
+		}
+	}
+	return out
+}
+
+// JSONRenderer prints the tree as one nested JSON object.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(entries <-chan redisview.Entry, sep string) {
+	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
+	buildTree(tree, entries, sep)
+	out, err := json.MarshalIndent(toJSONNode(*tree), "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Println(string(out))
+}
+
+// YAMLRenderer prints the same nested structure as JSONRenderer, as YAML.
+type YAMLRenderer struct{}
+
+func (YAMLRenderer) Render(entries <-chan redisview.Entry, sep string) {
+	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
+	buildTree(tree, entries, sep)
+	out, err := yaml.Marshal(toJSONNode(*tree))
+	if err != nil {
+		return
+	}
+	fmt.Print(string(out))
+}
+
+// ndjsonLine is one leaf, ready to be piped through jq.
+type ndjsonLine struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	TTL   int64       `json:"ttl"`
+	Value interface{} `json:"value"`
+}
+
+// NDJSONRenderer prints one JSON object per leaf key, newline-delimited, as
+// soon as each entry arrives from Walk rather than waiting for the full
+// scan to finish. Lines therefore come out in fetch-completion order, not
+// sorted by key — the right trade for "ideal for streaming through jq" on
+// a keyspace too large to buffer.
+type NDJSONRenderer struct{}
+
+func (NDJSONRenderer) Render(entries <-chan redisview.Entry, sep string) {
+	for entry := range entries {
+		line, err := json.Marshal(ndjsonLine{Key: entry.Key, Type: entry.Type, TTL: entry.TTL, Value: jsonSafeValue(entry.Value)})
+		if err == nil {
+			fmt.Println(string(line))
+		}
+	}
+}
+
+func rendererFor(format string) Renderer {
+	switch format {
+	case "json":
+		return JSONRenderer{}
+	case "ndjson":
+		return NDJSONRenderer{}
+	case "yaml":
+		return YAMLRenderer{}
+	default:
+		return TreeRenderer{}
+	}
+}
+
 func main() {
 	opt, err := docopt.Parse(usage, nil, false, "", false, false)
 	if err != nil {
@@ -251,7 +499,7 @@ func main() {
 
 	wrap = !opt["--nowrap"].(bool)
 
-	onlyKeys = opt["--only-keys"].(bool)
+	walkOpts.OnlyKeys = opt["--only-keys"].(bool)
 
 	turnOnColor = terminal.IsTerminal(int(os.Stdout.Fd()))
 
@@ -263,20 +511,80 @@ func main() {
 		redisURL = opt["--url"].(string)
 	}
 
+	if opt["--scan-count"] != nil {
+		n, err := strconv.Atoi(opt["--scan-count"].(string))
+		if err == nil && n > 0 {
+			walkOpts.ScanCount = n
+		}
+	}
+
+	if opt["--concurrency"] != nil {
+		n, err := strconv.Atoi(opt["--concurrency"].(string))
+		if err == nil && n > 0 {
+			walkOpts.Concurrency = n
+		}
+	}
+
+	clusterMode := opt["--cluster"].(bool)
+
+	sentinelSpec := ""
+	if opt["--sentinel"] != nil {
+		sentinelSpec = opt["--sentinel"].(string)
+	}
+
+	if opt["--stream-count"] != nil {
+		n, err := strconv.Atoi(opt["--stream-count"].(string))
+		if err == nil && n > 0 {
+			walkOpts.StreamCount = n
+		}
+	}
+
+	if opt["--as-bitmap"] != nil {
+		walkOpts.BitmapPattern = opt["--as-bitmap"].(string)
+	}
+
+	if opt["--geo"] != nil {
+		walkOpts.GeoPattern = opt["--geo"].(string)
+	}
+
+	if opt["--hll"] != nil {
+		walkOpts.HLLPattern = opt["--hll"].(string)
+	}
+
+	if opt["--type"] != nil {
+		walkOpts.TypeOverride = opt["--type"].(string)
+	}
+
+	if opt["--format"] != nil {
+		format = opt["--format"].(string)
+	}
+
+	if opt["--binary"] != nil {
+		binaryMode = opt["--binary"].(string)
+	}
+
+	tuiMode := opt["--tui"].(bool)
+
 	if ps := opt["PATTERN"].([]string); len(ps) != 0 {
 		patterns = ps
 	}
 
-	r := getConn()
+	rdb = connectRedis(redisURL, clusterMode, sentinelSpec)
 
-	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
-	for _, pattern := range patterns {
-		keys, err := r.Cmd("KEYS", pattern).List()
-		if err != nil {
-			continue
+	if tuiMode {
+		if err := runTUI(patterns, keySep); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
-		populate(tree, keys, keySep)
+		return
 	}
 
-	plot(*tree, "", "")
+	renderer := rendererFor(format)
+
+	entries, err := redisview.Walk(ctx, rdb, patterns, walkOpts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	renderer.Render(entries, keySep)
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dreamersdw/redis-view/pkg/redisview"
+	"gopkg.in/yaml.v2"
+)
+
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns
+// everything it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	w.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatalf("reading captured stdout: %v", err)
+	}
+	return buf.String()
+}
+
+// TestBuildTreeMergesMultiplePatterns is a regression test for the
+// chunk0-1 review fix: a single Walk call over several patterns must land
+// in one shared tree, not one tree per pattern.
+func TestBuildTreeMergesMultiplePatterns(t *testing.T) {
+	entries := make(chan redisview.Entry, 2)
+	entries <- redisview.Entry{Key: "tasks:1", Type: "string", TTL: -1, Value: "a"}
+	entries <- redisview.Entry{Key: "metrics:1", Type: "string", TTL: -1, Value: "b"}
+	close(entries)
+
+	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
+	buildTree(tree, entries, ":")
+
+	if len(tree.children) != 2 {
+		t.Fatalf("expected both patterns' keys in one tree, got %d top-level children", len(tree.children))
+	}
+	if _, ok := tree.children["tasks"]; !ok {
+		t.Errorf("missing tasks subtree")
+	}
+	if _, ok := tree.children["metrics"]; !ok {
+		t.Errorf("missing metrics subtree")
+	}
+}
+
+// TestIsBinary is a regression test for the two chunk0-6 bugs: the control
+// bytes being checked against the ASCII letters 'r'/'f'/'b' instead of the
+// escapes '\r'/'\f'/'\b', and a flat 30% threshold misclassifying short
+// valid UTF-8 strings as binary.
+func TestIsBinary(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", []byte{}, false},
+		{"plain ascii", []byte("hello world"), false},
+		{"short utf8 with accents", []byte("café"), false},
+		{"crlf control bytes", []byte("line one\r\nline two\r\n"), false},
+		{"formfeed and backspace", []byte("a\f\bb"), false},
+		{"invalid utf8 mostly control bytes", []byte{0xff, 0xfe, 0x00, 0x01, 0x02}, true},
+		{"png-like header", []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isBinary(c.data); got != c.want {
+				t.Errorf("isBinary(%v) = %v, want %v", c.data, got, c.want)
+			}
+		})
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	got := hexDump([]byte("AB"), "")
+	want := "00000000  41 42                                             |AB|"
+	if got != want {
+		t.Errorf("hexDump(AB) =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestJSONSafeValue is a regression test for the chunk0-4 review fix:
+// jsonSafeValue must base64-encode binary strings wherever they appear
+// (bare, in a slice, in a map, or nested in a StreamView's fields) instead
+// of letting json.Marshal mangle invalid UTF-8 into U+FFFD.
+func TestJSONSafeValue(t *testing.T) {
+	binary := string([]byte{0xff, 0xfe, 0x00})
+	wantB64 := "//4A"
+
+	if got := jsonSafeValue("hello"); got != "hello" {
+		t.Errorf("jsonSafeValue(text string) = %v, want unchanged", got)
+	}
+	if got := jsonSafeValue(binary); got != wantB64 {
+		t.Errorf("jsonSafeValue(binary string) = %v, want %v", got, wantB64)
+	}
+
+	slice := jsonSafeValue([]string{"ok", binary}).([]string)
+	if slice[0] != "ok" || slice[1] != wantB64 {
+		t.Errorf("jsonSafeValue([]string) = %v", slice)
+	}
+
+	m := jsonSafeValue(map[string]string{"k": binary}).(map[string]string)
+	if m["k"] != wantB64 {
+		t.Errorf("jsonSafeValue(map[string]string)[k] = %v, want %v", m["k"], wantB64)
+	}
+
+	sv := jsonSafeValue(redisview.StreamView{
+		Length:  1,
+		Entries: []redisview.StreamEntry{{ID: "1-1", Fields: map[string]string{"f": binary}}},
+	}).(redisview.StreamView)
+	if got := sv.Entries[0].Fields["f"]; got != wantB64 {
+		t.Errorf("jsonSafeValue(StreamView).Entries[0].Fields[f] = %v, want %v", got, wantB64)
+	}
+}
+
+// TestNDJSONRendererStreamsPerEntry is a regression test for the chunk0-1
+// review fix: NDJSONRenderer must emit each entry as it arrives on the
+// channel rather than buffering the whole scan into a tree first.
+func TestNDJSONRendererStreamsPerEntry(t *testing.T) {
+	entries := make(chan redisview.Entry, 2)
+	entries <- redisview.Entry{Key: "tasks:1", Type: "string", TTL: -1, Value: "a"}
+	entries <- redisview.Entry{Key: "tasks:2", Type: "string", TTL: 60, Value: "b"}
+	close(entries)
+
+	out := captureStdout(t, func() {
+		NDJSONRenderer{}.Render(entries, ":")
+	})
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	var lines []ndjsonLine
+	for scanner.Scan() {
+		var line ndjsonLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("unmarshaling ndjson line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 ndjson lines, got %d", len(lines))
+	}
+	if lines[0].Key != "tasks:1" || lines[0].TTL != -1 {
+		t.Errorf("unexpected first line: %+v", lines[0])
+	}
+	if lines[1].Key != "tasks:2" || lines[1].TTL != 60 {
+		t.Errorf("unexpected second line: %+v", lines[1])
+	}
+}
+
+// TestJSONRendererNestsByKeySegment is a regression test for chunk0-4:
+// JSONRenderer must mirror the key's colon-separated segments as nested
+// children rather than flattening the tree.
+func TestJSONRendererNestsByKeySegment(t *testing.T) {
+	entries := make(chan redisview.Entry, 1)
+	entries <- redisview.Entry{Key: "tasks:1", Type: "string", TTL: -1, Value: "a"}
+	close(entries)
+
+	out := captureStdout(t, func() {
+		JSONRenderer{}.Render(entries, ":")
+	})
+
+	var root jsonNode
+	if err := json.Unmarshal([]byte(out), &root); err != nil {
+		t.Fatalf("unmarshaling JSONRenderer output: %v\n%s", err, out)
+	}
+	tasks, ok := root.Children["tasks"]
+	if !ok {
+		t.Fatalf("missing tasks child in %s", out)
+	}
+	leaf, ok := tasks.Children["1"]
+	if !ok || leaf.Type != "string" || leaf.Value != "a" {
+		t.Errorf("unexpected leaf node: %+v", tasks.Children)
+	}
+}
+
+// TestYAMLRendererMatchesJSONRenderer is a regression test for chunk0-4:
+// YAMLRenderer renders the same nested structure as JSONRenderer, just as
+// YAML instead of JSON.
+func TestYAMLRendererMatchesJSONRenderer(t *testing.T) {
+	entries := make(chan redisview.Entry, 1)
+	entries <- redisview.Entry{Key: "tasks:1", Type: "string", TTL: -1, Value: "a"}
+	close(entries)
+
+	out := captureStdout(t, func() {
+		YAMLRenderer{}.Render(entries, ":")
+	})
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal([]byte(out), &root); err != nil {
+		t.Fatalf("unmarshaling YAMLRenderer output: %v\n%s", err, out)
+	}
+	if _, ok := root["children"]; !ok {
+		t.Errorf("expected top-level children key in yaml output, got %v", root)
+	}
+}
@@ -0,0 +1,444 @@
+// Package redisview implements the scan-and-fetch engine behind the
+// redis-view CLI: discovering keys with cursor-based SCAN and resolving
+// their type, TTL and value with pipelined batches. It is exported so other
+// Go programs can embed the same engine without forking the binary.
+package redisview
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StreamEntry is one XRANGE result, rendered as "id -> {field:value,...}".
+type StreamEntry struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// StreamView bundles XINFO STREAM's length alongside the first (oldest)
+// entries fetched via XRANGE.
+type StreamView struct {
+	Length  int64         `json:"length"`
+	Entries []StreamEntry `json:"entries"`
+}
+
+// BitmapView pairs BITCOUNT with a bitset rendering of the underlying bytes.
+type BitmapView struct {
+	Count int64  `json:"count"`
+	Bits  string `json:"bits"`
+}
+
+// GeoPoint is one GEOPOS result.
+type GeoPoint struct {
+	Lon float64 `json:"lon"`
+	Lat float64 `json:"lat"`
+}
+
+// Entry is one resolved key, streamed from Walk as soon as its batch
+// completes.
+type Entry struct {
+	Key   string
+	Type  string
+	TTL   int64
+	Value interface{}
+}
+
+// Options configures a Walk call.
+type Options struct {
+	ScanCount     int
+	Concurrency   int
+	OnlyKeys      bool
+	StreamCount   int
+	BitmapPattern string
+	GeoPattern    string
+	HLLPattern    string
+	TypeOverride  string
+}
+
+func (o Options) withDefaults() Options {
+	if o.ScanCount <= 0 {
+		o.ScanCount = 100
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	if o.StreamCount <= 0 {
+		o.StreamCount = 10
+	}
+	return o
+}
+
+// Walk discovers every key matching patterns and streams it, with its type,
+// TTL and value already resolved, as soon as its batch completes. In cluster
+// mode (detected by client being a *redis.ClusterClient) SCAN is fanned out
+// to every master and pipelines are grouped by hash slot.
+func Walk(ctx context.Context, client redis.Cmdable, patterns []string, opts Options) (<-chan Entry, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("redisview: at least one pattern is required")
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redisview: %w", err)
+	}
+	opts = opts.withDefaults()
+
+	keys := scanKeys(ctx, client, patterns, opts.ScanCount)
+	out := make(chan Entry)
+
+	go func() {
+		defer close(out)
+		var wg sync.WaitGroup
+		worker := func() {
+			defer wg.Done()
+			pending := make([]string, 0, opts.ScanCount)
+			flush := func() {
+				if len(pending) == 0 {
+					return
+				}
+				for _, entry := range fetchBatch(ctx, client, pending, opts) {
+					select {
+					case out <- entry:
+					case <-ctx.Done():
+						return
+					}
+				}
+				pending = pending[:0]
+			}
+			for key := range keys {
+				pending = append(pending, key)
+				if len(pending) >= opts.ScanCount {
+					flush()
+				}
+			}
+			flush()
+		}
+
+		wg.Add(opts.Concurrency)
+		for i := 0; i < opts.Concurrency; i++ {
+			go worker()
+		}
+		wg.Wait()
+	}()
+
+	return out, nil
+}
+
+// scanNode runs a single cursor-based SCAN loop for pattern against client,
+// emitting every discovered key to emit.
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string, batch int, emit func(string)) {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, int64(batch)).Result()
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			emit(key)
+		}
+		cursor = next
+		if cursor == 0 {
+			return
+		}
+	}
+}
+
+// scanKeys streams deduplicated keys for patterns, fanning each pattern out
+// to every master node when client is a cluster client.
+func scanKeys(ctx context.Context, client redis.Cmdable, patterns []string, batch int) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		var seen sync.Map
+		emit := func(key string) {
+			if _, loaded := seen.LoadOrStore(key, true); !loaded {
+				select {
+				case out <- key:
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		var wg sync.WaitGroup
+		for _, pattern := range patterns {
+			wg.Add(1)
+			go func(pattern string) {
+				defer wg.Done()
+				if cc, ok := client.(*redis.ClusterClient); ok {
+					cc.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+						scanNode(ctx, node, pattern, batch, emit)
+						return nil
+					})
+					return
+				}
+				scanNode(ctx, client, pattern, batch, emit)
+			}(pattern)
+		}
+		wg.Wait()
+	}()
+	return out
+}
+
+// classifyType picks the rendering type for key. A zset matching GeoPattern
+// becomes "geo"; every other non-string rtype passes through unchanged, so
+// TypeOverride/HLLPattern/BitmapPattern only ever reclassify string keys —
+// otherwise a list/hash/set/stream whose name happens to match one of these
+// patterns would get its value pipeline mis-dispatched (e.g. GET+BITCOUNT
+// against a hash) and silently come back blank. A BitmapPattern match
+// additionally requires a raw or embstr OBJECT ENCODING, since that's what
+// BITCOUNT/SETBIT expect; a TypeOverride of "bitmap" skips that check.
+func classifyType(ctx context.Context, client redis.Cmdable, key string, rtype string, opts Options) string {
+	if rtype == "zset" && opts.GeoPattern != "" && globMatch(opts.GeoPattern, key) {
+		return "geo"
+	}
+	if rtype != "string" {
+		return rtype
+	}
+	if opts.TypeOverride != "" {
+		return opts.TypeOverride
+	}
+	if opts.HLLPattern != "" && globMatch(opts.HLLPattern, key) {
+		return "hll"
+	}
+	if opts.BitmapPattern != "" && globMatch(opts.BitmapPattern, key) {
+		encoding, _ := client.ObjectEncoding(ctx, key).Result()
+		if encoding == "raw" || encoding == "embstr" {
+			return "bitmap"
+		}
+	}
+	return rtype
+}
+
+func globMatch(pattern string, key string) bool {
+	ok, err := path.Match(pattern, key)
+	return err == nil && ok
+}
+
+// fetchBatch resolves a batch of keys. In cluster mode the keys are first
+// grouped by hash slot so that every pipeline only targets keys living on
+// the same node.
+func fetchBatch(ctx context.Context, client redis.Cmdable, keys []string, opts Options) []Entry {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		return fetchGroup(ctx, client, keys, opts)
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for _, group := range groupBySlot(keys) {
+		entries = append(entries, fetchGroup(ctx, client, group, opts)...)
+	}
+	return entries
+}
+
+// fetchGroup resolves TYPE, TTL and the type-appropriate value for a batch of
+// keys that are known to share a single node, with two pipelined
+// round-trips instead of one round-trip per key.
+func fetchGroup(ctx context.Context, client redis.Cmdable, keys []string, opts Options) []Entry {
+	pipe := client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		typeCmds[i] = pipe.Type(ctx, key)
+		ttlCmds[i] = pipe.TTL(ctx, key)
+	}
+	pipe.Exec(ctx)
+
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		entries[i].Key = key
+		entries[i].Type, _ = typeCmds[i].Result()
+		entries[i].TTL = ttlSeconds(ttlCmds[i].Val())
+	}
+
+	if opts.OnlyKeys {
+		for i := range entries {
+			entries[i].Value = ""
+		}
+		return entries
+	}
+
+	for i := range entries {
+		entries[i].Type = classifyType(ctx, client, entries[i].Key, entries[i].Type, opts)
+	}
+
+	pipe = client.Pipeline()
+	stringCmds := make([]*redis.StringCmd, len(entries))
+	sliceCmds := make([]*redis.StringSliceCmd, len(entries))
+	hashCmds := make([]*redis.MapStringStringCmd, len(entries))
+	zsetCmds := make([]*redis.ZSliceCmd, len(entries))
+	hllCmds := make([]*redis.IntCmd, len(entries))
+	bitCountCmds := make([]*redis.IntCmd, len(entries))
+	for i, entry := range entries {
+		switch entry.Type {
+		case "string":
+			stringCmds[i] = pipe.Get(ctx, entry.Key)
+		case "list":
+			sliceCmds[i] = pipe.LRange(ctx, entry.Key, 0, -1)
+		case "set":
+			sliceCmds[i] = pipe.SMembers(ctx, entry.Key)
+		case "hash":
+			hashCmds[i] = pipe.HGetAll(ctx, entry.Key)
+		case "zset":
+			zsetCmds[i] = pipe.ZRangeWithScores(ctx, entry.Key, 0, -1)
+		case "hll":
+			hllCmds[i] = pipe.PFCount(ctx, entry.Key)
+		case "bitmap":
+			stringCmds[i] = pipe.Get(ctx, entry.Key)
+			bitCountCmds[i] = pipe.BitCount(ctx, entry.Key, nil)
+		}
+	}
+	pipe.Exec(ctx)
+	for i := range entries {
+		switch entries[i].Type {
+		case "string":
+			entries[i].Value, _ = stringCmds[i].Result()
+		case "list", "set":
+			entries[i].Value, _ = sliceCmds[i].Result()
+		case "hash":
+			entries[i].Value, _ = hashCmds[i].Result()
+		case "zset":
+			pairs, _ := zsetCmds[i].Result()
+			members := make(map[string]string, len(pairs))
+			for _, pair := range pairs {
+				member, _ := pair.Member.(string)
+				members[member] = strconv.FormatFloat(pair.Score, 'g', -1, 64)
+			}
+			entries[i].Value = members
+		case "hll":
+			entries[i].Value, _ = hllCmds[i].Result()
+		case "bitmap":
+			raw, _ := stringCmds[i].Result()
+			count, _ := bitCountCmds[i].Result()
+			entries[i].Value = BitmapView{Count: count, Bits: bitsetString([]byte(raw))}
+		}
+	}
+
+	// Streams and geosets need a member/entry list before their values can
+	// be fetched, so they are resolved with a couple of extra round-trips
+	// instead of folding into the generic pipeline above.
+	for i := range entries {
+		switch entries[i].Type {
+		case "stream":
+			entries[i].Value = fetchStream(ctx, client, entries[i].Key, opts.StreamCount)
+		case "geo":
+			entries[i].Value = fetchGeo(ctx, client, entries[i].Key)
+		}
+	}
+	return entries
+}
+
+// fetchStream renders a stream as its length (XINFO STREAM) plus its first
+// n entries, oldest first (XRANGE key - + COUNT n).
+func fetchStream(ctx context.Context, client redis.Cmdable, key string, n int) StreamView {
+	var view StreamView
+	if info, err := client.XInfoStream(ctx, key).Result(); err == nil {
+		view.Length = info.Length
+	}
+
+	msgs, _ := client.XRangeN(ctx, key, "-", "+", int64(n)).Result()
+	view.Entries = make([]StreamEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		values := make(map[string]string, len(msg.Values))
+		for field, v := range msg.Values {
+			values[field] = fmt.Sprint(v)
+		}
+		view.Entries = append(view.Entries, StreamEntry{ID: msg.ID, Fields: values})
+	}
+	return view
+}
+
+// fetchGeo resolves every member of a geoset (stored as a sorted set) to its
+// lon/lat pair via GEOPOS.
+func fetchGeo(ctx context.Context, client redis.Cmdable, key string) []GeoPoint {
+	members, _ := client.ZRange(ctx, key, 0, -1).Result()
+	if len(members) == 0 {
+		return nil
+	}
+
+	positions, err := client.GeoPos(ctx, key, members...).Result()
+	if err != nil {
+		return nil
+	}
+
+	points := make([]GeoPoint, 0, len(positions))
+	for _, p := range positions {
+		if p == nil {
+			continue
+		}
+		points = append(points, GeoPoint{Lon: p.Longitude, Lat: p.Latitude})
+	}
+	return points
+}
+
+// ttlSeconds converts the sentinel-bearing Duration TTL() returns (-1 for no
+// expiry, -2 for a missing key) back into the integer seconds the rest of
+// the program expects.
+func ttlSeconds(d time.Duration) int64 {
+	switch d {
+	case -1, -2:
+		return int64(d)
+	default:
+		return int64(d / time.Second)
+	}
+}
+
+// crc16 implements the CCITT (XModem) polynomial Redis Cluster uses to map
+// keys onto the 16384 hash slots.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// hashSlot returns the Redis Cluster slot for key, honouring {hashtag} so
+// that multi-key commands against related keys land on the same node.
+func hashSlot(key string) uint16 {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			if tag := key[start+1 : start+1+end]; tag != "" {
+				key = tag
+			}
+		}
+	}
+	return crc16([]byte(key)) % 16384
+}
+
+// groupBySlot buckets keys by hash slot so a pipelined batch only ever
+// targets keys that live on the same cluster node.
+func groupBySlot(keys []string) map[uint16][]string {
+	groups := make(map[uint16][]string)
+	for _, key := range keys {
+		slot := hashSlot(key)
+		groups[slot] = append(groups[slot], key)
+	}
+	return groups
+}
+
+// bitsetString renders bytes as a string of '0'/'1' characters, msb first.
+func bitsetString(bytes []byte) string {
+	seq := make([]byte, 8*len(bytes))
+	for index, char := range bytes {
+		for i := 0; i < 8; i++ {
+			bit := (char >> uint(i)) & 0x1
+			if bit == 0 {
+				seq[index*8+(7-i)] = '0'
+			} else {
+				seq[index*8+(7-i)] = '1'
+			}
+		}
+	}
+	return string(seq)
+}
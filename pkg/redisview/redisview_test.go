@@ -0,0 +1,98 @@
+package redisview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// crc16("123456789") == 0x31C3 is the standard CRC-16/XMODEM check value,
+// and is also the one Redis Cluster's own test suite uses.
+func TestCRC16(t *testing.T) {
+	got := crc16([]byte("123456789"))
+	if want := uint16(0x31C3); got != want {
+		t.Errorf("crc16(123456789) = %#04x, want %#04x", got, want)
+	}
+}
+
+func TestHashSlotKnownVector(t *testing.T) {
+	if got, want := hashSlot("foo"), uint16(12182); got != want {
+		t.Errorf("hashSlot(foo) = %d, want %d", got, want)
+	}
+}
+
+func TestHashSlotHonoursHashtag(t *testing.T) {
+	a := hashSlot("{user1000}.following")
+	b := hashSlot("{user1000}.followers")
+	if a != b {
+		t.Errorf("keys sharing a {user1000} hashtag landed on different slots: %d vs %d", a, b)
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"places:*", "places:1", true},
+		{"places:*", "flags:1", false},
+		{"*", "anything", true},
+	}
+	for _, c := range cases {
+		if got := globMatch(c.pattern, c.key); got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.key, got, c.want)
+		}
+	}
+}
+
+// TestClassifyType is a regression test for the review fix: GeoPattern only
+// reclassifies actual zsets, and TypeOverride/HLLPattern only reclassify
+// actual strings — a list/hash/set whose name happens to match one of these
+// patterns must pass through unchanged instead of being coerced into a type
+// its value pipeline can't actually satisfy.
+func TestClassifyType(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name  string
+		key   string
+		rtype string
+		opts  Options
+		want  string
+	}{
+		{"geo pattern on a zset becomes geo", "geo:1", "zset", Options{GeoPattern: "geo:*"}, "geo"},
+		{"geo pattern on a hash is left alone", "geo:1", "hash", Options{GeoPattern: "geo:*"}, "hash"},
+		{"type override on a list is left alone", "flags:1", "list", Options{TypeOverride: "bitmap"}, "list"},
+		{"type override on a string wins", "flags:1", "string", Options{TypeOverride: "bitmap"}, "bitmap"},
+		{"hll pattern on a string becomes hll", "visitors:1", "string", Options{HLLPattern: "visitors:*"}, "hll"},
+		{"no patterns passes rtype through", "plain", "string", Options{}, "string"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := classifyType(ctx, nil, c.key, c.rtype, c.opts)
+			if got != c.want {
+				t.Errorf("classifyType(%q, %q, %+v) = %q, want %q", c.key, c.rtype, c.opts, got, c.want)
+			}
+		})
+	}
+}
+
+// TestWalkSurfacesConnectionError is a regression test for the review fix:
+// Walk used to start an empty, silently-failing scan against an unreachable
+// server instead of reporting the error, matching neither the old tool's
+// "unable to connect" exit 1 nor a caller's expectations.
+func TestWalkSurfacesConnectionError(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 200 * time.Millisecond,
+	})
+	defer client.Close()
+
+	_, err := Walk(context.Background(), client, []string{"*"}, Options{})
+	if err == nil {
+		t.Fatal("Walk against an unreachable server returned nil error")
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dreamersdw/redis-view/pkg/redisview"
+)
+
+// tuiLine is one flattened, visible row of the left-pane tree.
+type tuiLine struct {
+	path   string // full key path, sep-joined
+	label  string
+	depth  int
+	isLeaf bool
+}
+
+// tuiModel drives the --tui mode: the tree materializes progressively as
+// entries arrive on the redisview.Walk channel, same as the other
+// renderers, but a collapsible pane lets the user drill down instead of
+// printing everything at once.
+type tuiModel struct {
+	tree      *treeNode
+	entries   <-chan redisview.Entry
+	cancel    context.CancelFunc
+	pattern   string
+	sep       string
+	collapsed map[string]bool
+	lines     []tuiLine
+	cursor    int
+	scanned   int
+	status    string
+
+	filtering  bool
+	filter     string
+	editingTTL bool
+	ttlInput   string
+	confirmDel bool
+
+	// scanGen identifies the current Walk's channel; it is bumped on every
+	// rescan so a stale waitForEntry goroutine still reading the previous
+	// channel can be told apart from the current one and ignored.
+	scanGen int
+
+	width, height int
+}
+
+func newTUIModel(tree *treeNode, entries <-chan redisview.Entry, cancel context.CancelFunc, pattern string, sep string) tuiModel {
+	m := tuiModel{
+		tree:      tree,
+		entries:   entries,
+		cancel:    cancel,
+		pattern:   pattern,
+		sep:       sep,
+		collapsed: make(map[string]bool),
+		width:     100,
+		height:    30,
+	}
+	m.rebuildLines()
+	return m
+}
+
+type entryMsg struct {
+	entry redisview.Entry
+	ok    bool
+	gen   int
+}
+
+// waitForEntry tags the read with gen (the Walk generation it belongs to)
+// so Update can tell a stale in-flight read, queued against a channel that
+// has since been superseded by a rescan, apart from the current one.
+func waitForEntry(ch <-chan redisview.Entry, gen int) tea.Cmd {
+	return func() tea.Msg {
+		entry, ok := <-ch
+		return entryMsg{entry: entry, ok: ok, gen: gen}
+	}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return waitForEntry(m.entries, m.scanGen)
+}
+
+// rebuildLines flattens the tree into the visible rows, skipping the
+// children of any path the user has collapsed.
+func (m *tuiModel) rebuildLines() {
+	m.lines = m.lines[:0]
+	var walk func(node treeNode, path string, depth int)
+	walk = func(node treeNode, path string, depth int) {
+		if path != "" {
+			if m.filter != "" && !strings.Contains(path, m.filter) && len(node.children) == 0 {
+				return
+			}
+			m.lines = append(m.lines, tuiLine{
+				path:   path,
+				label:  node.value,
+				depth:  depth,
+				isLeaf: len(node.children) == 0,
+			})
+		}
+		if path != "" && m.collapsed[path] {
+			return
+		}
+		for _, part := range mapKeys(node.children) {
+			childPath := part
+			if path != "" {
+				childPath = path + m.sep + part
+			}
+			walk(node.children[part], childPath, depth+1)
+		}
+	}
+	walk(*m.tree, "", 0)
+	if m.cursor >= len(m.lines) {
+		m.cursor = len(m.lines) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *tuiModel) selectedNode() (treeNode, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.lines) {
+		return treeNode{}, false
+	}
+	path := m.lines[m.cursor].path
+	node := *m.tree
+	for _, part := range strings.Split(path, m.sep) {
+		child, ok := node.children[part]
+		if !ok {
+			return treeNode{}, false
+		}
+		node = child
+	}
+	return node, true
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case entryMsg:
+		if msg.gen != m.scanGen {
+			return m, nil
+		}
+		if !msg.ok {
+			m.status = fmt.Sprintf("scan complete: %d keys", m.scanned)
+			return m, nil
+		}
+		populateEntry(m.tree, msg.entry, m.sep)
+		m.scanned++
+		m.rebuildLines()
+		m.status = fmt.Sprintf("scanned %d keys, %d matches", m.scanned, len(m.lines))
+		return m, waitForEntry(m.entries, m.scanGen)
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filtering {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.filtering = false
+			m.filter = ""
+			m.rebuildLines()
+		case tea.KeyEnter:
+			m.filtering = false
+		case tea.KeyBackspace:
+			if len(m.filter) > 0 {
+				m.filter = m.filter[:len(m.filter)-1]
+			}
+			m.rebuildLines()
+		default:
+			m.filter += msg.String()
+			m.rebuildLines()
+		}
+		return m, nil
+	}
+
+	if m.editingTTL {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.editingTTL = false
+			m.ttlInput = ""
+		case tea.KeyEnter:
+			m.editingTTL = false
+			m.applyTTL()
+		case tea.KeyBackspace:
+			if len(m.ttlInput) > 0 {
+				m.ttlInput = m.ttlInput[:len(m.ttlInput)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes {
+				m.ttlInput += msg.String()
+			}
+		}
+		return m, nil
+	}
+
+	if m.confirmDel {
+		switch msg.String() {
+		case "y":
+			m.confirmDel = false
+			m.deleteSelected()
+		default:
+			m.confirmDel = false
+			m.status = "delete cancelled"
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.lines)-1 {
+			m.cursor++
+		}
+	case "left":
+		if node, ok := m.selectedNode(); ok && len(node.children) > 0 {
+			m.collapsed[m.lines[m.cursor].path] = true
+			m.rebuildLines()
+		}
+	case "right":
+		if node, ok := m.selectedNode(); ok && len(node.children) > 0 {
+			delete(m.collapsed, m.lines[m.cursor].path)
+			m.rebuildLines()
+		}
+	case "/":
+		m.filtering = true
+		m.filter = ""
+	case "t":
+		m.showMeta()
+	case "e":
+		m.editingTTL = true
+		m.ttlInput = ""
+	case "d":
+		if node, ok := m.selectedNode(); ok && node.fetched {
+			m.confirmDel = true
+		}
+	case "r":
+		return m, m.rescanSelected()
+	case "y":
+		m.yank(false)
+	case "Y":
+		m.yank(true)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) showMeta() {
+	node, ok := m.selectedNode()
+	if !ok || !node.fetched {
+		return
+	}
+	key := m.lines[m.cursor].path
+	encoding, _ := rdb.ObjectEncoding(ctx, key).Result()
+	usage, _ := rdb.MemoryUsage(ctx, key).Result()
+	m.status = fmt.Sprintf("%s  type=%s  encoding=%s  memory=%d bytes", key, node.rtype, encoding, usage)
+}
+
+func (m *tuiModel) applyTTL() {
+	node, ok := m.selectedNode()
+	if !ok {
+		return
+	}
+	key := m.lines[m.cursor].path
+	if m.ttlInput == "" {
+		rdb.Persist(ctx, key)
+		m.status = fmt.Sprintf("persisted %s", key)
+	} else {
+		seconds, err := strconv.Atoi(m.ttlInput)
+		if err != nil {
+			m.status = fmt.Sprintf("invalid ttl %q", m.ttlInput)
+			m.ttlInput = ""
+			return
+		}
+		rdb.Expire(ctx, key, time.Duration(seconds)*time.Second)
+		m.status = fmt.Sprintf("set ttl of %s to %ds", key, seconds)
+	}
+	_ = node
+	m.ttlInput = ""
+}
+
+func (m *tuiModel) deleteSelected() {
+	node, ok := m.selectedNode()
+	if !ok || !node.fetched {
+		return
+	}
+	key := m.lines[m.cursor].path
+	if err := rdb.Del(ctx, key).Err(); err != nil {
+		m.status = fmt.Sprintf("delete failed: %v", err)
+		return
+	}
+	m.removeNode(key)
+	m.status = fmt.Sprintf("deleted %s", key)
+}
+
+// removeNode deletes the node at path from the tree after a successful DEL,
+// so the left pane doesn't keep showing a key that no longer exists until
+// the user manually rescans.
+func (m *tuiModel) removeNode(path string) {
+	parts := strings.Split(path, m.sep)
+	parent := *m.tree
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := parent.children[part]
+		if !ok {
+			return
+		}
+		parent = child
+	}
+	delete(parent.children, parts[len(parts)-1])
+	m.rebuildLines()
+}
+
+// rescanSelected re-runs SCAN rooted at the currently selected key, for
+// when a subtree has drifted since the initial walk.
+func (m *tuiModel) rescanSelected() tea.Cmd {
+	node, ok := m.selectedNode()
+	if !ok || node.fetched {
+		return nil
+	}
+	prefix := m.lines[m.cursor].path + m.sep + "*"
+	walkCtx, cancel := context.WithCancel(ctx)
+	entries, err := redisview.Walk(walkCtx, rdb, []string{prefix}, walkOpts)
+	if err != nil {
+		cancel()
+		m.status = err.Error()
+		return nil
+	}
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = cancel
+	m.entries = entries
+	m.scanGen++
+	m.status = fmt.Sprintf("rescanning %s", prefix)
+	return waitForEntry(m.entries, m.scanGen)
+}
+
+func (m *tuiModel) yank(value bool) {
+	node, ok := m.selectedNode()
+	if !ok {
+		return
+	}
+	key := m.lines[m.cursor].path
+	text := key
+	if value && node.fetched {
+		text = prettyPrint(node.val, "", false, true)
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		m.status = fmt.Sprintf("clipboard error: %v", err)
+		return
+	}
+	if value {
+		m.status = fmt.Sprintf("yanked value of %s", key)
+	} else {
+		m.status = fmt.Sprintf("yanked key %s", key)
+	}
+}
+
+func (m tuiModel) View() string {
+	leftWidth := m.width / 2
+	if leftWidth < 20 {
+		leftWidth = 20
+	}
+
+	var left strings.Builder
+	for i, line := range m.lines {
+		prefix := strings.Repeat("  ", line.depth)
+		marker := " "
+		if m.collapsed[line.path] {
+			marker = "+"
+		} else if !line.isLeaf {
+			marker = "-"
+		}
+		row := fmt.Sprintf("%s%s %s", prefix, marker, line.label)
+		if i == m.cursor {
+			row = colorize(row, "black:white")
+		}
+		left.WriteString(row)
+		left.WriteString("\n")
+	}
+
+	var right strings.Builder
+	if node, ok := m.selectedNode(); ok && node.fetched {
+		fmt.Fprintf(&right, "type: %s\nttl:  %d\n\n%s\n", node.rtype, node.ttl, prettyPrint(node.val, "", true, true))
+	}
+
+	var body strings.Builder
+	body.WriteString(left.String())
+	body.WriteString("\n")
+	body.WriteString(right.String())
+
+	input := ""
+	switch {
+	case m.filtering:
+		input = fmt.Sprintf("/%s", m.filter)
+	case m.editingTTL:
+		input = fmt.Sprintf("ttl> %s (enter to apply, empty+enter to PERSIST)", m.ttlInput)
+	case m.confirmDel:
+		input = fmt.Sprintf("delete %s? (y/n)", m.lines[m.cursor].path)
+	default:
+		input = m.status
+	}
+
+	return fmt.Sprintf("%s\n%s", body.String(), input)
+}
+
+// runTUI launches the interactive tree browser for patterns, streaming keys
+// from redisview.Walk just like the other renderers.
+func runTUI(patterns []string, sep string) error {
+	walkCtx, cancel := context.WithCancel(ctx)
+	entries, err := redisview.Walk(walkCtx, rdb, patterns, walkOpts)
+	if err != nil {
+		cancel()
+		return err
+	}
+	tree := &treeNode{value: "/", children: make(map[string]treeNode)}
+	model := newTUIModel(tree, entries, cancel, strings.Join(patterns, ","), sep)
+	program := tea.NewProgram(model, tea.WithAltScreen())
+	finalModel, err := program.Run()
+	if final, ok := finalModel.(tuiModel); ok && final.cancel != nil {
+		final.cancel()
+	}
+	return err
+}